@@ -31,6 +31,24 @@ func (h Home) Logs() string {
 	return h.Path("logs")
 }
 
+// Cache returns the path to the duffle cache directory, where remote
+// repository data fetched over the network is kept.
+func (h Home) Cache() string {
+	return h.Path("cache")
+}
+
+// Repositories returns the path to the cache subtree holding one directory
+// per configured remote repository.
+func (h Home) Repositories() string {
+	return h.Path("cache", "repository")
+}
+
+// Keyring returns the path to the Duffle public keyring used to verify
+// signed repository indexes and bundles.
+func (h Home) Keyring() string {
+	return h.Path("pubring.gpg")
+}
+
 // Plugins returns the path to the Duffle plugins.
 func (h Home) Plugins() string {
 	plugdirs := os.Getenv(PluginEnvVar)
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extract unpacks a plugin archive into destDir. The archive format (gzipped
+// tar or zip) is detected from its leading bytes.
+func extract(data []byte, destDir string) error {
+	switch {
+	case len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b:
+		return extractTarGz(data, destDir)
+	case len(data) >= 2 && data[0] == 'P' && data[1] == 'K':
+		return extractZip(data, destDir)
+	default:
+		return fmt.Errorf("unrecognized plugin archive format")
+	}
+}
+
+func extractTarGz(data []byte, destDir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir, tar.TypeReg:
+			// Only plain files and directories are ever extracted.
+		default:
+			return fmt.Errorf("refusing to extract %q: unsupported tar entry type", hdr.Name)
+		}
+		if err := writeEntry(destDir, hdr.Name, hdr.FileInfo(), tr); err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(data []byte, destDir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		fi := f.FileInfo()
+		if !fi.IsDir() && !fi.Mode().IsRegular() {
+			return fmt.Errorf("refusing to extract %q: unsupported zip entry type", f.Name)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeEntry(destDir, f.Name, fi, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEntry writes a single archive entry into destDir, refusing to write
+// outside of it: a maliciously crafted entry name like "../../etc/passwd"
+// or an absolute path must never escape destDir (a "zip slip").
+func writeEntry(destDir, name string, fi os.FileInfo, r io.Reader) error {
+	target, err := safeJoin(destDir, name)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return os.MkdirAll(target, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, r)
+	return err
+}
+
+// safeJoin joins name onto destDir and verifies the result still lives
+// inside destDir, rejecting any entry name that would escape it via "../"
+// components or an absolute path.
+func safeJoin(destDir, name string) (string, error) {
+	cleanDest := filepath.Clean(destDir)
+	target := filepath.Clean(filepath.Join(cleanDest, name))
+
+	if target != cleanDest && !strings.HasPrefix(target, cleanDest+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry %q escapes destination directory", name)
+	}
+	return target, nil
+}
@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// InstalledPlugin records the resolved version of a plugin installed into
+// home.Home.Plugins().
+type InstalledPlugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Digest  string `json:"digest"`
+}
+
+// InstalledState is the on-disk record of every plugin currently installed,
+// persisted at home.Home.Path("plugins", "installed.json").
+type InstalledState struct {
+	Plugins []InstalledPlugin `json:"plugins"`
+}
+
+func loadInstalledState(path string) (*InstalledState, error) {
+	state := &InstalledState{}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *InstalledState) writeFile(path string) error {
+	b, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+func (s *InstalledState) find(name string) *InstalledPlugin {
+	for i := range s.Plugins {
+		if s.Plugins[i].Name == name {
+			return &s.Plugins[i]
+		}
+	}
+	return nil
+}
+
+func (s *InstalledState) set(p InstalledPlugin) {
+	if existing := s.find(p.Name); existing != nil {
+		*existing = p
+		return
+	}
+	s.Plugins = append(s.Plugins, p)
+}
+
+func (s *InstalledState) remove(name string) bool {
+	for i, p := range s.Plugins {
+		if p.Name == name {
+			s.Plugins = append(s.Plugins[:i], s.Plugins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
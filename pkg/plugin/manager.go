@@ -0,0 +1,131 @@
+// Package plugin implements duffle's plugin manager: installing, upgrading
+// and removing plugins resolved from a registry whose index reuses the
+// repo.Index format.
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jcsirot/duffle/pkg/duffle/home"
+	"github.com/jcsirot/duffle/pkg/repo"
+)
+
+// Manager installs, upgrades, and removes duffle plugins.
+type Manager struct {
+	home          home.Home
+	registry      repo.Repository
+	duffleVersion string
+}
+
+// NewManager creates a Manager that installs plugins under h, resolving
+// them against registry and checking each plugin's Require constraint
+// against duffleVersion (typically version.Version).
+func NewManager(h home.Home, registry repo.Repository, duffleVersion string) *Manager {
+	return &Manager{home: h, registry: registry, duffleVersion: duffleVersion}
+}
+
+func (m *Manager) installedPath() string {
+	return m.home.Path("plugins", "installed.json")
+}
+
+// List returns every plugin currently installed.
+func (m *Manager) List() ([]InstalledPlugin, error) {
+	state, err := loadInstalledState(m.installedPath())
+	if err != nil {
+		return nil, err
+	}
+	return state.Plugins, nil
+}
+
+// Install resolves name against versionConstraint and every plugin it
+// transitively requires, then downloads and installs whichever of them
+// aren't already present at the resolved version. The whole resolution is
+// solved up front: if any dependency would conflict with an already
+// installed plugin, nothing is downloaded.
+func (m *Manager) Install(name, versionConstraint string) error {
+	idx, err := m.registry.Fetch()
+	if err != nil {
+		return fmt.Errorf("fetching plugin registry: %v", err)
+	}
+
+	state, err := loadInstalledState(m.installedPath())
+	if err != nil {
+		return err
+	}
+
+	plan, err := solve(idx, state, name, versionConstraint, m.duffleVersion)
+	if err != nil {
+		return err
+	}
+
+	for _, md := range plan {
+		if err := m.installOne(md, state); err != nil {
+			return err
+		}
+	}
+	return state.writeFile(m.installedPath())
+}
+
+// Upgrade re-resolves name against versionConstraint and installs the
+// result, even if some version is already installed.
+func (m *Manager) Upgrade(name, versionConstraint string) error {
+	return m.Install(name, versionConstraint)
+}
+
+// Remove deletes the named plugin from disk and from the installed state.
+func (m *Manager) Remove(name string) error {
+	state, err := loadInstalledState(m.installedPath())
+	if err != nil {
+		return err
+	}
+	if !state.remove(name) {
+		return fmt.Errorf("plugin %q is not installed", name)
+	}
+	if err := os.RemoveAll(filepath.Join(m.home.Plugins(), name)); err != nil {
+		return err
+	}
+	return state.writeFile(m.installedPath())
+}
+
+func (m *Manager) installOne(md Metadata, state *InstalledState) error {
+	if existing := state.find(md.Name); existing != nil && existing.Version == md.Version {
+		return nil
+	}
+
+	digest, err := m.registry.Resolve(md.Name, md.Version)
+	if err != nil {
+		return fmt.Errorf("resolving %s %s: %v", md.Name, md.Version, err)
+	}
+
+	fetcher, ok := m.registry.(repo.ArtifactFetcher)
+	if !ok {
+		return fmt.Errorf("registry does not support fetching plugin artifacts")
+	}
+	rc, err := fetcher.FetchArtifact(digest)
+	if err != nil {
+		return fmt.Errorf("downloading %s %s: %v", md.Name, md.Version, err)
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	dest := filepath.Join(m.home.Plugins(), md.Name)
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	if err := extract(data, dest); err != nil {
+		return err
+	}
+
+	state.set(InstalledPlugin{Name: md.Name, Version: md.Version, Digest: digest})
+	return nil
+}
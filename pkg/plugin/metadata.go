@@ -0,0 +1,55 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jcsirot/duffle/pkg/repo"
+)
+
+// Annotation keys under which plugin-specific constraints are recorded in a
+// repo.Metadata entry, since the shared index format has no first-class
+// fields for them.
+const (
+	annotationRequire  = "duffle.io/plugin-require"
+	annotationRequires = "duffle.io/plugin-requires"
+)
+
+// Dependency is a constraint on another plugin that must be resolved and
+// installed alongside the plugin that declares it.
+type Dependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Metadata describes a single, resolved plugin version.
+type Metadata struct {
+	Name    string
+	Version string
+	// Require is the semver constraint on the running duffle version that
+	// this plugin version is compatible with.
+	Require string
+	// Requires lists the other plugins this version depends on.
+	Requires []Dependency
+}
+
+// decodeMetadata reads the Metadata for name/version out of idx's
+// annotations.
+func decodeMetadata(idx *repo.Index, name, version string) (Metadata, error) {
+	bv := idx.Find(name, version)
+	if bv == nil {
+		return Metadata{}, fmt.Errorf("plugin %q %s not found in registry", name, version)
+	}
+
+	md := Metadata{
+		Name:    name,
+		Version: version,
+		Require: bv.Annotations[annotationRequire],
+	}
+	if raw := bv.Annotations[annotationRequires]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &md.Requires); err != nil {
+			return Metadata{}, fmt.Errorf("plugin %q %s has an invalid %s annotation: %v", name, version, annotationRequires, err)
+		}
+	}
+	return md, nil
+}
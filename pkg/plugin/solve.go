@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/jcsirot/duffle/pkg/repo"
+)
+
+// solve resolves name (constrained by versionConstraint) and every plugin
+// it transitively Requires into a flat installation plan, checking each
+// candidate's own Require constraint against duffleVersion and refusing
+// any resolution that would conflict with an already-installed plugin.
+func solve(idx *repo.Index, installed *InstalledState, name, versionConstraint, duffleVersion string) ([]Metadata, error) {
+	seen := map[string]Metadata{}
+	// name is the explicit install/upgrade target, not a dependency: it is
+	// always allowed to move to whatever versionConstraint asks for, even
+	// across a version range its currently-installed copy doesn't satisfy.
+	if err := resolveOne(idx, installed, seen, name, versionConstraint, duffleVersion, false); err != nil {
+		return nil, err
+	}
+
+	plan := make([]Metadata, 0, len(seen))
+	for _, md := range seen {
+		plan = append(plan, md)
+	}
+	return plan, nil
+}
+
+// resolveOne resolves name/versionConstraint and recurses into its
+// dependencies. isDependency is false only for the top-level install/
+// upgrade target; it is true for every plugin pulled in transitively by
+// someone else's Requires list, where an already-installed, unrelated
+// consumer needs protecting from being silently moved to an incompatible
+// version.
+func resolveOne(idx *repo.Index, installed *InstalledState, seen map[string]Metadata, name, versionConstraint, duffleVersion string, isDependency bool) error {
+	if existing, ok := seen[name]; ok {
+		return checkConstraint(name, existing.Version, versionConstraint)
+	}
+
+	res, err := idx.Resolve(name, versionConstraint, repo.ResolveOptions{})
+	if err != nil {
+		return fmt.Errorf("resolving plugin %q: %v", name, err)
+	}
+
+	md, err := decodeMetadata(idx, name, res.Version)
+	if err != nil {
+		return err
+	}
+
+	if md.Require != "" {
+		c, err := semver.NewConstraint(md.Require)
+		if err != nil {
+			return fmt.Errorf("plugin %q %s has an invalid require constraint %q: %v", name, md.Version, md.Require, err)
+		}
+		v, err := semver.NewVersion(duffleVersion)
+		if err != nil {
+			return fmt.Errorf("invalid duffle version %q: %v", duffleVersion, err)
+		}
+		if !c.Check(v) {
+			return fmt.Errorf("plugin %q %s requires duffle %s, but this is duffle %s", name, md.Version, md.Require, duffleVersion)
+		}
+	}
+
+	if isDependency {
+		if pinned := installed.find(name); pinned != nil && pinned.Version != md.Version && !constraintAllows(versionConstraint, pinned.Version) {
+			return fmt.Errorf("plugin %q is already installed at %s, which does not satisfy the requested constraint %q", name, pinned.Version, versionConstraint)
+		}
+	}
+
+	seen[name] = md
+
+	for _, dep := range md.Requires {
+		if err := resolveOne(idx, installed, seen, dep.Name, dep.Version, duffleVersion, true); err != nil {
+			return fmt.Errorf("dependency of %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func checkConstraint(name, version, constraint string) error {
+	if constraint == "" {
+		return nil
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return err
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return err
+	}
+	if !c.Check(v) {
+		return fmt.Errorf("conflicting requirements for plugin %q: already resolved to %s, which does not satisfy %q", name, version, constraint)
+	}
+	return nil
+}
+
+func constraintAllows(constraint, version string) bool {
+	if constraint == "" {
+		return true
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	return c.Check(v)
+}
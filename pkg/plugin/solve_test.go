@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jcsirot/duffle/pkg/repo"
+)
+
+func addPluginVersion(idx *repo.Index, name, version, require string, requires []Dependency) {
+	annotations := map[string]string{}
+	if require != "" {
+		annotations[annotationRequire] = require
+	}
+	if len(requires) > 0 {
+		b, _ := json.Marshal(requires)
+		annotations[annotationRequires] = string(b)
+	}
+	idx.Add(repo.Metadata{Name: name, Version: version, Annotations: annotations}, "sha256:"+name+"-"+version, 0, time.Time{})
+}
+
+func TestSolve_ExplicitUpgradeCrossesVersionRanges(t *testing.T) {
+	idx := repo.NewIndex()
+	addPluginVersion(idx, "foo", "1.2.0", "", nil)
+	addPluginVersion(idx, "foo", "2.0.0", "", nil)
+
+	installed := &InstalledState{Plugins: []InstalledPlugin{{Name: "foo", Version: "1.2.0", Digest: "sha256:foo-1.2.0"}}}
+
+	plan, err := solve(idx, installed, "foo", "^2.0.0", "1.0.0")
+	if err != nil {
+		t.Fatalf("expected upgrading the explicit target to succeed, got: %v", err)
+	}
+	if len(plan) != 1 || plan[0].Version != "2.0.0" {
+		t.Fatalf("expected plan to resolve foo to 2.0.0, got %+v", plan)
+	}
+}
+
+func TestSolve_RefusesDependencyThatConflictsWithInstalledConsumer(t *testing.T) {
+	idx := repo.NewIndex()
+	addPluginVersion(idx, "bar", "2.0.0", "", nil)
+	addPluginVersion(idx, "top", "1.0.0", "", []Dependency{{Name: "bar", Version: "^2.0.0"}})
+
+	installed := &InstalledState{Plugins: []InstalledPlugin{{Name: "bar", Version: "1.0.0", Digest: "sha256:bar-1.0.0"}}}
+
+	if _, err := solve(idx, installed, "top", "1.0.0", "1.0.0"); err == nil {
+		t.Fatal("expected solving a dependency that conflicts with an already-installed plugin to fail")
+	}
+}
+
+func TestSolve_RejectsIncompatibleDuffleVersion(t *testing.T) {
+	idx := repo.NewIndex()
+	addPluginVersion(idx, "foo", "1.0.0", "^2.0.0", nil)
+
+	if _, err := solve(idx, &InstalledState{}, "foo", "1.0.0", "1.0.0"); err == nil {
+		t.Fatal("expected a plugin requiring an incompatible duffle version to be rejected")
+	}
+}
@@ -0,0 +1,31 @@
+// Package provenance provides detached, OpenPGP-style signatures used to
+// verify that a bundle repository index (and the bundles it references) has
+// not been tampered with since it was published.
+package provenance
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func clearSign(entity *openpgp.Entity, digest string) (string, error) {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, entity, strings.NewReader(digest), nil); err != nil {
+		return "", fmt.Errorf("failed to sign digest: %v", err)
+	}
+	return buf.String(), nil
+}
+
+func verify(keyring openpgp.EntityList, digest, signature string) error {
+	if len(keyring) == 0 {
+		return fmt.Errorf("no public keys available to verify signature")
+	}
+	_, err := openpgp.CheckArmoredDetachedSignature(keyring, strings.NewReader(digest), strings.NewReader(signature))
+	if err != nil {
+		return fmt.Errorf("signature verification failed: %v", err)
+	}
+	return nil
+}
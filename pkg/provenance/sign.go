@@ -0,0 +1,68 @@
+package provenance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// ErrNotSigned indicates that an entry was expected to carry a signature but did not.
+var ErrNotSigned = errors.New("not signed")
+
+// Signatory signs and verifies digests using an OpenPGP keyring.
+//
+// A Signatory with a private key loaded can produce detached, armored
+// signatures. Any Signatory can verify a signature against a public keyring.
+type Signatory struct {
+	// Entity holds the key used to create new signatures. It may be nil for
+	// a Signatory that is only used to verify.
+	Entity *openpgp.Entity
+	// KeyRing is the public keyring used to verify signatures.
+	KeyRing openpgp.EntityList
+}
+
+// NewFromKeyring loads a Signatory from the given public keyring file.
+//
+// The keyring may be either ASCII-armored or raw binary OpenPGP packets;
+// armored keyrings are tried first since that is what `gpg --export` and
+// duffle's own tooling produce.
+//
+// The returned Signatory can verify signatures but cannot create new ones.
+func NewFromKeyring(keyring string) (*Signatory, error) {
+	f, err := os.Open(keyring)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		if _, serr := f.Seek(0, os.SEEK_SET); serr != nil {
+			return nil, fmt.Errorf("failed to load keyring %q: %v", keyring, err)
+		}
+		ring, err = openpgp.ReadKeyRing(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load keyring %q: %v", keyring, err)
+		}
+	}
+	return &Signatory{KeyRing: ring}, nil
+}
+
+// ClearSign produces a detached, ASCII-armored signature over digest.
+func (s *Signatory) ClearSign(digest string) (string, error) {
+	if s.Entity == nil {
+		return "", errors.New("no signing key loaded for this signatory")
+	}
+	return clearSign(s.Entity, digest)
+}
+
+// Verify checks that signature is a valid signature over digest produced by a
+// key in the Signatory's keyring.
+func (s *Signatory) Verify(digest, signature string) error {
+	if signature == "" {
+		return ErrNotSigned
+	}
+	return verify(s.KeyRing, digest, signature)
+}
@@ -0,0 +1,95 @@
+package provenance
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func newTestEntity(t *testing.T) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test User", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatalf("NewEntity: %v", err)
+	}
+	return entity
+}
+
+func writeTestKeyring(t *testing.T, entity *openpgp.Entity) string {
+	t.Helper()
+
+	f, err := ioutil.TempFile("", "keyring-*.gpg")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	defer f.Close()
+
+	w, err := armor.Encode(f, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor.Encode: %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close armor writer: %v", err)
+	}
+	return f.Name()
+}
+
+func TestClearSignAndVerify_RoundTrip(t *testing.T) {
+	entity := newTestEntity(t)
+	keyringPath := writeTestKeyring(t, entity)
+	defer os.Remove(keyringPath)
+
+	signer := &Signatory{Entity: entity}
+	sig, err := signer.ClearSign("sha256:abc")
+	if err != nil {
+		t.Fatalf("ClearSign: %v", err)
+	}
+
+	verifier, err := NewFromKeyring(keyringPath)
+	if err != nil {
+		t.Fatalf("NewFromKeyring: %v", err)
+	}
+	if err := verifier.Verify("sha256:abc", sig); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestVerify_RejectsTamperedDigest(t *testing.T) {
+	entity := newTestEntity(t)
+	keyringPath := writeTestKeyring(t, entity)
+	defer os.Remove(keyringPath)
+
+	signer := &Signatory{Entity: entity}
+	sig, err := signer.ClearSign("sha256:abc")
+	if err != nil {
+		t.Fatalf("ClearSign: %v", err)
+	}
+
+	verifier, err := NewFromKeyring(keyringPath)
+	if err != nil {
+		t.Fatalf("NewFromKeyring: %v", err)
+	}
+	if err := verifier.Verify("sha256:different", sig); err == nil {
+		t.Fatal("expected verification of a tampered digest to fail")
+	}
+}
+
+func TestVerify_RejectsEmptySignature(t *testing.T) {
+	entity := newTestEntity(t)
+	keyringPath := writeTestKeyring(t, entity)
+	defer os.Remove(keyringPath)
+
+	verifier, err := NewFromKeyring(keyringPath)
+	if err != nil {
+		t.Fatalf("NewFromKeyring: %v", err)
+	}
+	if err := verifier.Verify("sha256:abc", ""); err != ErrNotSigned {
+		t.Fatalf("expected ErrNotSigned, got %v", err)
+	}
+}
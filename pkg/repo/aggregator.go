@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Aggregator merges the indexes of multiple Repository backends into a
+// single, searchable Index, as though every configured repository were
+// one big one.
+type Aggregator struct {
+	repos []Repository
+}
+
+// NewAggregator creates an Aggregator over the given repositories.
+func NewAggregator(repos []Repository) *Aggregator {
+	return &Aggregator{repos: repos}
+}
+
+// Index fetches every configured repository and merges their indexes.
+//
+// A repository that fails to fetch does not prevent the others from being
+// merged; its error is collected and returned alongside whatever could be
+// merged from the rest.
+func (a *Aggregator) Index() (*Index, error) {
+	merged := NewIndex()
+
+	var errs []string
+	for _, r := range a.repos {
+		idx, err := r.Fetch()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.Name(), err))
+			continue
+		}
+		merged.Merge(idx)
+	}
+
+	if len(errs) > 0 {
+		return merged, fmt.Errorf("failed to fetch %d of %d repositories: %s", len(errs), len(a.repos), strings.Join(errs, "; "))
+	}
+	return merged, nil
+}
+
+// Update refreshes every configured repository in parallel, returning once
+// all fetches have completed.
+func (a *Aggregator) Update() error {
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(a.repos))
+
+	for _, r := range a.repos {
+		wg.Add(1)
+		go func(r Repository) {
+			defer wg.Done()
+			if _, err := r.Fetch(); err != nil {
+				errCh <- fmt.Errorf("%s: %v", r.Name(), err)
+			}
+		}(r)
+	}
+	wg.Wait()
+	close(errCh)
+
+	var errs []string
+	for err := range errCh {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update %d repositories: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return nil
+}
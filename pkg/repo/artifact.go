@@ -0,0 +1,13 @@
+package repo
+
+import "io"
+
+// ArtifactFetcher is implemented by Repository backends that can retrieve
+// the raw bytes of a previously pushed artifact by its content digest.
+//
+// Not every Repository supports this: an OCIRepository, for example, has no
+// name-independent way to address a blob, so consumers should type-assert
+// before relying on it.
+type ArtifactFetcher interface {
+	FetchArtifact(digest string) (io.ReadCloser, error)
+}
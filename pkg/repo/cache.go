@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// CacheMeta records the HTTP validators last seen for a repository's index,
+// so a later Fetch can issue a conditional GET and skip the download
+// entirely when the remote has not changed.
+type CacheMeta struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt,omitempty"`
+}
+
+func loadCacheMeta(dir string) CacheMeta {
+	var m CacheMeta
+	data, err := ioutil.ReadFile(filepath.Join(dir, "meta.json"))
+	if err != nil {
+		return m
+	}
+	// A corrupt cache file is treated the same as a missing one: the next
+	// Fetch simply re-downloads.
+	json.Unmarshal(data, &m) // nolint: errcheck
+	return m
+}
+
+func saveCacheMeta(dir string, m CacheMeta) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "meta.json"), b, 0644)
+}
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// RepoConfig describes one configured remote repository.
+type RepoConfig struct {
+	Name string `toml:"name"`
+	URL  string `toml:"url"`
+}
+
+// Config is the subset of the duffle configuration file concerned with
+// remote repositories.
+type Config struct {
+	Repositories []RepoConfig `toml:"repositories"`
+}
+
+// LoadConfig reads the duffle configuration file at path.
+//
+// A missing file is not an error; it is treated as an empty configuration
+// so a fresh DUFFLE_HOME works without first creating a config.toml.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if _, err := toml.Decode(string(data), cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Add adds r to the configuration, replacing any existing entry with the
+// same name.
+func (c *Config) Add(r RepoConfig) {
+	for i, existing := range c.Repositories {
+		if existing.Name == r.Name {
+			c.Repositories[i] = r
+			return
+		}
+	}
+	c.Repositories = append(c.Repositories, r)
+}
+
+// Remove deletes the repository entry with the given name, reporting
+// whether one was found.
+func (c *Config) Remove(name string) bool {
+	for i, existing := range c.Repositories {
+		if existing.Name == name {
+			c.Repositories = append(c.Repositories[:i], c.Repositories[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// WriteFile persists the configuration as TOML to the given path.
+func (c *Config) WriteFile(path string, mode os.FileMode) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), mode)
+}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BundleFetcher downloads bundle artifacts referenced from an Index,
+// caching them on disk and recording an Origin sidecar describing where
+// each one came from.
+type BundleFetcher struct {
+	Repository Repository
+	CacheDir   string
+}
+
+// NewBundleFetcher creates a BundleFetcher that caches artifacts fetched
+// from repository under cacheDir (typically
+// filepath.Join(h.Repositories(), repository.Name()) for a home.Home h).
+func NewBundleFetcher(repository Repository, cacheDir string) *BundleFetcher {
+	return &BundleFetcher{Repository: repository, CacheDir: cacheDir}
+}
+
+// Fetch resolves name/version against idx and downloads its bundle
+// artifact, returning the path to the cached copy.
+//
+// If the repository supports conditional fetches and a previous Origin
+// sidecar for the same digest is on disk, Fetch consults the remote's
+// ETag before downloading anything; when the remote confirms nothing has
+// changed, the cached copy is reused.
+func (f *BundleFetcher) Fetch(idx *Index, name, version string) (string, error) {
+	bv := idx.find(name, version)
+	if bv == nil {
+		return "", ErrNoBundleVersion
+	}
+
+	artifactPath := filepath.Join(f.CacheDir, bv.Digest+".tgz")
+	origin, _ := loadOrigin(f.CacheDir, bv.Digest)
+
+	var (
+		rc   io.ReadCloser
+		etag string
+		err  error
+	)
+
+	if cond, ok := f.Repository.(ConditionalArtifactFetcher); ok {
+		prevETag := ""
+		if origin != nil {
+			prevETag = origin.ETag
+		}
+
+		var changed bool
+		rc, etag, changed, err = cond.FetchArtifactIfChanged(bv.Digest, prevETag)
+		if err != nil {
+			return "", fmt.Errorf("fetching %s %s: %v", name, version, err)
+		}
+		if !changed {
+			if _, statErr := os.Stat(artifactPath); statErr == nil {
+				idx.setOrigin(name, bv.Version, origin)
+				return artifactPath, nil
+			}
+			// The remote says nothing changed, but the cached artifact is
+			// missing locally; fall through to a full fetch.
+		}
+	}
+
+	if rc == nil {
+		fetcher, ok := f.Repository.(ArtifactFetcher)
+		if !ok {
+			return "", fmt.Errorf("repository %q does not support fetching bundle artifacts", f.Repository.Name())
+		}
+		if rc, err = fetcher.FetchArtifact(bv.Digest); err != nil {
+			return "", fmt.Errorf("fetching %s %s: %v", name, version, err)
+		}
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(f.CacheDir, 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(artifactPath, data, 0644); err != nil {
+		return "", err
+	}
+
+	o := &Origin{
+		Source:    bv.Source,
+		VCS:       bv.VCS,
+		Revision:  bv.Revision,
+		Digest:    bv.Digest,
+		ETag:      etag,
+		FetchedAt: time.Now(),
+	}
+	if err := o.writeFile(f.CacheDir); err != nil {
+		return "", err
+	}
+	idx.setOrigin(name, bv.Version, o)
+
+	return artifactPath, nil
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+type fakeConditionalRepo struct {
+	name             string
+	data             []byte
+	etag             string
+	conditionalCalls int
+	downloads        int
+}
+
+func (f *fakeConditionalRepo) Name() string                               { return f.name }
+func (f *fakeConditionalRepo) Fetch() (*Index, error)                     { return NewIndex(), nil }
+func (f *fakeConditionalRepo) ListVersions(name string) ([]string, error) { return nil, nil }
+func (f *fakeConditionalRepo) Resolve(name, version string) (string, error) {
+	return "", nil
+}
+func (f *fakeConditionalRepo) Push(md Metadata, artifact io.Reader) (*BundleVersion, error) {
+	return nil, nil
+}
+
+func (f *fakeConditionalRepo) FetchArtifactIfChanged(digest, etag string) (io.ReadCloser, string, bool, error) {
+	f.conditionalCalls++
+	if etag == f.etag {
+		return nil, f.etag, false, nil
+	}
+	f.downloads++
+	return ioutil.NopCloser(bytes.NewReader(f.data)), f.etag, true, nil
+}
+
+func TestBundleFetcher_ShortCircuitsUnchangedArtifact(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "duffle-bundle-cache")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	idx := NewIndex()
+	idx.Add(Metadata{Name: "mybundle", Version: "1.0.0", Source: "https://example.com/mybundle"}, "sha256:abc", 0, time.Now())
+
+	repoFake := &fakeConditionalRepo{name: "test", data: []byte("bundle-bytes"), etag: `"etag-1"`}
+	fetcher := NewBundleFetcher(repoFake, cacheDir)
+
+	path1, err := fetcher.Fetch(idx, "mybundle", "1.0.0")
+	if err != nil {
+		t.Fatalf("first Fetch: %v", err)
+	}
+	if repoFake.downloads != 1 {
+		t.Fatalf("expected one artifact download, got %d", repoFake.downloads)
+	}
+
+	origin, err := idx.Origin("mybundle", "1.0.0")
+	if err != nil {
+		t.Fatalf("Origin: %v", err)
+	}
+	if origin.Source != "https://example.com/mybundle" {
+		t.Fatalf("expected origin to record the bundle source, got %q", origin.Source)
+	}
+
+	path2, err := fetcher.Fetch(idx, "mybundle", "1.0.0")
+	if err != nil {
+		t.Fatalf("second Fetch: %v", err)
+	}
+	if path1 != path2 {
+		t.Fatalf("expected the same cached artifact path, got %q and %q", path1, path2)
+	}
+	if repoFake.downloads != 1 {
+		t.Fatalf("expected the second Fetch to short-circuit and not re-download, got %d downloads", repoFake.downloads)
+	}
+	if repoFake.conditionalCalls != 2 {
+		t.Fatalf("expected two conditional checks against the remote, got %d", repoFake.conditionalCalls)
+	}
+}
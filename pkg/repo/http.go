@@ -0,0 +1,213 @@
+package repo
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// HTTPRepository is a Repository backed by a plain HTTP(S) endpoint that
+// serves an index.json (and, optionally, an index.json.prov) at its URL.
+type HTTPRepository struct {
+	name     string
+	url      string
+	cacheDir string
+	client   *http.Client
+}
+
+// NewHTTPRepository creates an HTTPRepository for the given name and index
+// URL, caching fetched data under cacheDir.
+func NewHTTPRepository(name, url, cacheDir string) *HTTPRepository {
+	return &HTTPRepository{name: name, url: url, cacheDir: cacheDir, client: http.DefaultClient}
+}
+
+// Name returns the configured name of the repository.
+func (r *HTTPRepository) Name() string { return r.name }
+
+func (r *HTTPRepository) indexCachePath() string {
+	return filepath.Join(r.cacheDir, "index.json")
+}
+
+// Fetch downloads the repository's index, issuing a conditional GET against
+// the previously recorded ETag/Last-Modified when a cached copy exists. It
+// also fetches and caches the index's ".prov" signature, if the remote
+// serves one, so a later Index.Verify has something to check.
+func (r *HTTPRepository) Fetch() (*Index, error) {
+	meta := loadCacheMeta(r.cacheDir)
+
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return LoadIndex(r.indexCachePath())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", r.url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(r.cacheDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(r.indexCachePath(), data, 0644); err != nil {
+		return nil, err
+	}
+
+	if err := saveCacheMeta(r.cacheDir, CacheMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+
+	prov, err := r.fetchProvenance()
+	if err != nil {
+		return nil, err
+	}
+	if prov != "" {
+		if err := ioutil.WriteFile(r.indexCachePath()+provSuffix, []byte(prov), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	idx, err := LoadIndexBuffer(data)
+	if err != nil {
+		return nil, err
+	}
+	idx.provenance = prov
+	return idx, nil
+}
+
+// fetchProvenance downloads the detached signature for the index, if the
+// remote serves one at "<url>.prov". A missing sidecar is not an error: it
+// just means the index is unsigned, and Index.Verify will refuse it.
+func (r *HTTPRepository) fetchProvenance() (string, error) {
+	resp, err := r.client.Get(r.url + provSuffix)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s: unexpected status %s", r.url+provSuffix, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ListVersions returns the known versions of name in the repository's index.
+func (r *HTTPRepository) ListVersions(name string) ([]string, error) {
+	idx, err := r.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	vs, ok := idx.Entries[name]
+	if !ok {
+		return nil, ErrNoBundleName
+	}
+	versions := make([]string, 0, len(vs))
+	for _, v := range vs {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+// Resolve returns the digest for name/version from the repository's index.
+func (r *HTTPRepository) Resolve(name, version string) (string, error) {
+	idx, err := r.Fetch()
+	if err != nil {
+		return "", err
+	}
+	return idx.Get(name, version)
+}
+
+// Push is not supported by a plain HTTP repository: its index is read-only
+// from duffle's point of view and must be published out of band.
+func (r *HTTPRepository) Push(md Metadata, artifact io.Reader) (*BundleVersion, error) {
+	return nil, fmt.Errorf("repository %q does not support push", r.name)
+}
+
+// FetchArtifact downloads the artifact stored under digest, which is
+// expected to live alongside the index as "<digest>.tgz".
+func (r *HTTPRepository) FetchArtifact(digest string) (io.ReadCloser, error) {
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(path.Dir(u.Path), digest+".tgz")
+
+	resp, err := r.client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", u.String(), resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// FetchArtifactIfChanged behaves like FetchArtifact, but issues a
+// conditional GET using etag and reports changed=false without downloading
+// anything when the remote confirms the artifact has not changed.
+func (r *HTTPRepository) FetchArtifactIfChanged(digest, etag string) (io.ReadCloser, string, bool, error) {
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return nil, "", false, err
+	}
+	u.Path = path.Join(path.Dir(u.Path), digest+".tgz")
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, etag, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", false, fmt.Errorf("fetching %s: unexpected status %s", u.String(), resp.Status)
+	}
+	return resp.Body, resp.Header.Get("ETag"), true, nil
+}
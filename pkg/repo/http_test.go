@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestHTTPRepositoryFetch_UsesConditionalGET(t *testing.T) {
+	const indexBody = `{"apiVersion":"v1","entries":{"mybundle":[{"name":"mybundle","version":"1.0.0","digest":"sha256:abc"}]}}`
+
+	var gets, notModified int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		if r.Header.Get("If-None-Match") == `"etag-1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"etag-1"`)
+		w.Write([]byte(indexBody))
+	})
+	mux.HandleFunc("/index.json.prov", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cacheDir, err := ioutil.TempDir("", "duffle-repo-cache")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	repoHTTP := NewHTTPRepository("test", srv.URL+"/index.json", cacheDir)
+
+	if _, err := repoHTTP.Fetch(); err != nil {
+		t.Fatalf("first Fetch: %v", err)
+	}
+	if gets != 1 {
+		t.Fatalf("expected 1 GET to the index, got %d", gets)
+	}
+
+	if _, err := repoHTTP.Fetch(); err != nil {
+		t.Fatalf("second Fetch: %v", err)
+	}
+	if gets != 2 {
+		t.Fatalf("expected the second Fetch to issue a conditional GET, got %d total GETs", gets)
+	}
+	if notModified != 1 {
+		t.Fatalf("expected the second GET to carry the recorded ETag and be answered 304, got %d 304 responses", notModified)
+	}
+}
+
+func TestHTTPRepositoryFetch_RejectsMissingSignatureOnVerify(t *testing.T) {
+	const indexBody = `{"apiVersion":"v1","entries":{"mybundle":[{"name":"mybundle","version":"1.0.0","digest":"sha256:abc"}]}}`
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(indexBody))
+	})
+	mux.HandleFunc("/index.json.prov", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cacheDir, err := ioutil.TempDir("", "duffle-repo-cache")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(cacheDir)
+
+	repoHTTP := NewHTTPRepository("test", srv.URL+"/index.json", cacheDir)
+
+	idx, err := repoHTTP.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if err := idx.Verify("/nonexistent/keyring.gpg"); err == nil {
+		t.Fatal("expected Verify to fail when the HTTP repository served no .prov sidecar")
+	}
+}
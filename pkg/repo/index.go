@@ -2,15 +2,25 @@ package repo
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
+
+	"github.com/jcsirot/duffle/pkg/provenance"
 )
 
+// provSuffix is appended to an index path to find its detached signature.
+const provSuffix = ".prov"
+
 var (
 	// ErrNoAPIVersion indicates that an API version was not specified.
 	ErrNoAPIVersion = errors.New("no API version specified")
@@ -20,114 +30,498 @@ var (
 	ErrNoBundleName = errors.New("no bundle name found")
 )
 
-// Index defines a list of bundle repositories, each repository's respective tags and the digest reference.
-type Index map[string]map[string]string
+// APIVersionV1 is the API version understood by this version of duffle.
+const APIVersionV1 = "v1"
+
+// incompatibleSuffix marks a version as predating the bundle's adoption of
+// semantic versioning, mirroring the Go module resolver's "+incompatible"
+// build metadata for legacy majors.
+const incompatibleSuffix = "+incompatible"
+
+// Maintainer describes a person or organization responsible for a bundle.
+type Maintainer struct {
+	// Name is the maintainer's name.
+	Name string `json:"name,omitempty"`
+	// Email is the maintainer's email address.
+	Email string `json:"email,omitempty"`
+}
+
+// Metadata describes a bundle version without requiring access to the bundle itself.
+type Metadata struct {
+	// Name is the name of the bundle.
+	Name string `json:"name"`
+	// Version is the semantic version of the bundle.
+	Version string `json:"version"`
+	// Description is a one-line summary of the bundle.
+	Description string `json:"description,omitempty"`
+	// Keywords are search terms associated with the bundle.
+	Keywords []string `json:"keywords,omitempty"`
+	// Maintainers lists the people or organizations responsible for the bundle.
+	Maintainers []Maintainer `json:"maintainers,omitempty"`
+	// Source is the URL of the bundle's source repository.
+	Source string `json:"source,omitempty"`
+	// VCS is the version control system backing Source, if known (e.g. "git").
+	VCS string `json:"vcs,omitempty"`
+	// Revision is the VCS commit or tag the bundle was built from, if known.
+	Revision string `json:"revision,omitempty"`
+	// Annotations holds arbitrary subsystem-specific metadata that doesn't
+	// warrant a first-class field here, keyed and interpreted by the
+	// consumer (for example, the plugin manager records a plugin's duffle
+	// and dependency constraints under its own annotation keys).
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// BundleVersion is a single, indexed entry for a bundle: its descriptive
+// Metadata plus the information needed to fetch and verify it.
+type BundleVersion struct {
+	Metadata
+
+	// Created is the time this entry was added to the index.
+	Created time.Time `json:"created,omitempty"`
+	// Size is the size in bytes of the bundle artifact.
+	Size int64 `json:"size,omitempty"`
+	// Digest is the content digest of the bundle artifact.
+	Digest string `json:"digest,omitempty"`
+	// Signatures holds zero or more detached signatures covering Digest.
+	Signatures []string `json:"signatures,omitempty"`
+}
+
+// BundleVersions is a list of BundleVersion, sortable by Version descending.
+type BundleVersions []*BundleVersion
+
+func (b BundleVersions) Len() int      { return len(b) }
+func (b BundleVersions) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b BundleVersions) Less(i, j int) bool {
+	vi, erri := semver.NewVersion(strings.TrimSuffix(b[i].Version, incompatibleSuffix))
+	vj, errj := semver.NewVersion(strings.TrimSuffix(b[j].Version, incompatibleSuffix))
+	if erri != nil || errj != nil {
+		// A malformed version never panics a sort; it simply sorts after
+		// every valid version.
+		return erri == nil
+	}
+	return vi.GreaterThan(vj)
+}
+
+// Index defines a list of bundle repositories, their available versions and
+// the metadata and digests needed to resolve and verify them.
+type Index struct {
+	// APIVersion is the version of the index schema.
+	APIVersion string `json:"apiVersion"`
+	// Generated is the time the index file was last written.
+	Generated time.Time `json:"generated,omitempty"`
+	// Entries maps a bundle name to its known versions.
+	Entries map[string]BundleVersions `json:"entries"`
+
+	// provenance holds the detached, armored signature loaded from the
+	// index's ".prov" sidecar file, if any.
+	provenance string
+
+	// origins records where each fetched bundle artifact actually came
+	// from, keyed by originKey(name, version). It is populated by a
+	// BundleFetcher as bundles are downloaded, not by LoadIndex.
+	origins map[string]*Origin
+}
+
+// Origin returns where the cached artifact for name/version actually came
+// from, as recorded by the BundleFetcher that last downloaded it.
+func (i *Index) Origin(name, version string) (*Origin, error) {
+	bv := i.find(name, version)
+	if bv == nil {
+		return nil, ErrNoBundleVersion
+	}
+	o, ok := i.origins[originKey(name, bv.Version)]
+	if !ok {
+		return nil, fmt.Errorf("no origin recorded for %s %s", name, bv.Version)
+	}
+	return o, nil
+}
+
+func (i *Index) setOrigin(name, version string, o *Origin) {
+	if i.origins == nil {
+		i.origins = map[string]*Origin{}
+	}
+	i.origins[originKey(name, version)] = o
+}
+
+func originKey(name, version string) string {
+	return name + "@" + version
+}
+
+// NewIndex creates a new, empty Index.
+func NewIndex() *Index {
+	return &Index{
+		APIVersion: APIVersionV1,
+		Generated:  time.Now(),
+		Entries:    map[string]BundleVersions{},
+	}
+}
 
 // LoadIndex takes a file at the given path and returns an Index object
-func LoadIndex(path string) (Index, error) {
+//
+// If a "<path>.prov" sidecar file exists alongside it, its detached
+// signature is loaded too, so a later call to Verify or VerifiedGet can
+// check it without re-reading the file system.
+func LoadIndex(path string) (*Index, error) {
 	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0644)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return loadIndex(f)
+
+	idx, err := loadIndex(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if sig, err := ioutil.ReadFile(path + provSuffix); err == nil {
+		idx.provenance = string(sig)
+	}
+	return idx, nil
 }
 
 // LoadIndexReader takes a reader and returns an Index object
-func LoadIndexReader(r io.Reader) (Index, error) {
+func LoadIndexReader(r io.Reader) (*Index, error) {
 	return loadIndex(r)
 }
 
 // LoadIndexBuffer reads repository metadata from a JSON byte stream
-func LoadIndexBuffer(data []byte) (Index, error) {
+func LoadIndexBuffer(data []byte) (*Index, error) {
 	return loadIndex(bytes.NewBuffer(data))
 }
 
-// Add adds a new entry to the index
-func (i Index) Add(name, version string, digest string) {
-	if tags, ok := i[name]; ok {
-		tags[version] = digest
-	} else {
-		i[name] = map[string]string{
-			version: digest,
-		}
+// Add adds a new entry to the index.
+func (i *Index) Add(md Metadata, digest string, size int64, created time.Time) {
+	bv := &BundleVersion{
+		Metadata: md,
+		Created:  created,
+		Size:     size,
+		Digest:   digest,
 	}
+	i.Entries[md.Name] = append(i.Entries[md.Name], bv)
+	sort.Sort(i.Entries[md.Name])
 }
 
 // Has returns true if the index has an entry for a bundle with the given name and exact version.
-func (i Index) Has(name, version string) bool {
+func (i *Index) Has(name, version string) bool {
 	_, err := i.Get(name, version)
 	return err == nil
 }
 
 // Get returns the digest for the given name.
 //
-// If version is empty, this will return the digest for the bundle with the highest version.
-func (i Index) Get(name, version string) (string, error) {
-	vs, ok := i[name]
+// If version is empty, this will return the digest for the bundle with the
+// highest non-prerelease, non-"+incompatible" version. For finer control
+// over prerelease and "+incompatible" matching, use Resolve.
+func (i *Index) Get(name, version string) (string, error) {
+	res, err := i.Resolve(name, version, ResolveOptions{})
+	if err != nil {
+		return "", err
+	}
+	return res.Digest, nil
+}
+
+// ResolveOptions controls how Index.Resolve matches a version constraint
+// against an index's entries.
+type ResolveOptions struct {
+	// AllowPrerelease permits a prerelease version (e.g. "1.2.3-beta.1") to
+	// satisfy a constraint that does not itself reference a prerelease.
+	AllowPrerelease bool
+	// AllowIncompatible permits a version carrying a "+incompatible" build
+	// suffix to satisfy the constraint.
+	AllowIncompatible bool
+	// Preferred, if set, is tried as an exact version match before falling
+	// back to constraint resolution.
+	Preferred string
+}
+
+// Resolution is the outcome of resolving a version constraint against an
+// Index.
+type Resolution struct {
+	// Version is the resolved version, including any "+incompatible" suffix.
+	Version string
+	// Digest is the content digest of the resolved bundle version.
+	Digest string
+	// Incompatible is true when Version carries a "+incompatible" suffix.
+	Incompatible bool
+}
+
+// Resolve finds the best bundle version for name matching constraint,
+// according to opts.
+//
+// Candidates are considered in semver-descending order, so the result is
+// deterministic regardless of map iteration order. A prerelease version is
+// skipped unless constraint itself references a prerelease or
+// opts.AllowPrerelease is set. A version carrying a "+incompatible" suffix
+// — mirroring how the Go module resolver treats legacy majors that never
+// adopted semantic import versioning — is skipped unless
+// opts.AllowIncompatible is set.
+func (i *Index) Resolve(name, constraint string, opts ResolveOptions) (*Resolution, error) {
+	vs, ok := i.Entries[name]
 	if !ok {
-		return "", ErrNoBundleName
+		return nil, ErrNoBundleName
 	}
 	if len(vs) == 0 {
-		return "", ErrNoBundleVersion
+		return nil, ErrNoBundleVersion
+	}
+
+	if opts.Preferred != "" {
+		if bv := i.find(name, opts.Preferred); bv != nil {
+			return resolutionOf(bv), nil
+		}
 	}
 
-	var constraint *semver.Constraints
-	if len(version) == 0 {
-		constraint, _ = semver.NewConstraint("*")
+	var c *semver.Constraints
+	if constraint == "" {
+		c, _ = semver.NewConstraint("*")
 	} else {
 		var err error
-		constraint, err = semver.NewConstraint(version)
+		c, err = semver.NewConstraint(constraint)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 	}
+	// A hyphen range ("1.0.0 - 2.0.0") contains "-" without naming a
+	// prerelease, so its " - " separator is ignored here.
+	namesPrerelease := strings.Contains(strings.Replace(constraint, " - ", " ", -1), "-")
+	allowPrerelease := opts.AllowPrerelease || namesPrerelease
 
-	for ver, digest := range vs {
-		test, err := semver.NewVersion(ver)
-		if err != nil {
+	for _, bv := range vs {
+		incompatible := strings.HasSuffix(bv.Version, incompatibleSuffix)
+		if incompatible && !opts.AllowIncompatible {
 			continue
 		}
 
-		if constraint.Check(test) {
-			return digest, nil
+		test, err := semver.NewVersion(strings.TrimSuffix(bv.Version, incompatibleSuffix))
+		if err != nil {
+			continue
+		}
+		if test.Prerelease() != "" && !allowPrerelease {
+			continue
+		}
+		// Masterminds/semver constraints never match a prerelease version
+		// unless the constraint itself carries one. If constraint names a
+		// prerelease, it already targets this candidate directly and must
+		// be checked as-is; only a prerelease let through by opts, against
+		// a constraint that says nothing about prereleases, needs its
+		// prerelease stripped before the check.
+		chk := test
+		if test.Prerelease() != "" && !namesPrerelease {
+			chk, err = semver.NewVersion(fmt.Sprintf("%d.%d.%d", test.Major(), test.Minor(), test.Patch()))
+			if err != nil {
+				continue
+			}
 		}
+		if !c.Check(chk) {
+			continue
+		}
+		return resolutionOf(bv), nil
+	}
+	return nil, ErrNoBundleVersion
+}
+
+func resolutionOf(bv *BundleVersion) *Resolution {
+	return &Resolution{
+		Version:      bv.Version,
+		Digest:       bv.Digest,
+		Incompatible: strings.HasSuffix(bv.Version, incompatibleSuffix),
 	}
-	return "", ErrNoBundleVersion
 }
 
 // WriteFile writes an index file to the given destination path.
 //
 // The mode on the file is set to 'mode'.
-func (i Index) WriteFile(dest string, mode os.FileMode) error {
+func (i *Index) WriteFile(dest string, mode os.FileMode) error {
+	_, err := i.writeFile(dest, mode)
+	return err
+}
+
+// WriteFileSigned writes an index file to the given destination path and
+// signs it with s, writing the detached, armored signature to
+// "<dest>.prov".
+func (i *Index) WriteFileSigned(dest string, mode os.FileMode, s *provenance.Signatory) error {
+	b, err := i.writeFile(dest, mode)
+	if err != nil {
+		return err
+	}
+
+	sig, err := s.ClearSign(digestBytes(b))
+	if err != nil {
+		return fmt.Errorf("failed to sign index: %v", err)
+	}
+	return ioutil.WriteFile(dest+provSuffix, []byte(sig), mode)
+}
+
+// writeFile marshals the index, updating Generated, and returns the bytes
+// written so callers can sign over exactly what landed on disk.
+func (i *Index) writeFile(dest string, mode os.FileMode) ([]byte, error) {
+	i.Generated = time.Now()
 	b, err := json.MarshalIndent(i, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return b, ioutil.WriteFile(dest, b, mode)
+}
+
+// Verify checks the index's own ".prov" signature, loaded by LoadIndex, and
+// the per-entry signature of every bundle version that carries one. It
+// fails closed: an index with no ".prov" signature at all is treated as
+// unsigned and rejected, rather than silently reported as verified.
+func (i *Index) Verify(keyring string) error {
+	if i.provenance == "" {
+		return fmt.Errorf("index: %v", provenance.ErrNotSigned)
+	}
+
+	sig, err := provenance.NewFromKeyring(keyring)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(i.withoutProvenance(), "", "    ")
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(dest, b, mode)
+	if err := sig.Verify(digestBytes(b), i.provenance); err != nil {
+		return fmt.Errorf("index: %v", err)
+	}
+
+	for name, versions := range i.Entries {
+		for _, bv := range versions {
+			for _, s := range bv.Signatures {
+				if err := sig.Verify(bv.Digest, s); err != nil {
+					return fmt.Errorf("%s %s: %v", name, bv.Version, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// VerifiedGet behaves like Get, but refuses to return a digest whose entry
+// does not carry at least one signature that validates against keyring.
+func (i *Index) VerifiedGet(name, version, keyring string) (string, error) {
+	digest, err := i.Get(name, version)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := provenance.NewFromKeyring(keyring)
+	if err != nil {
+		return "", err
+	}
+
+	bv := i.findByDigest(name, digest)
+	if bv == nil || len(bv.Signatures) == 0 {
+		return "", provenance.ErrNotSigned
+	}
+	for _, s := range bv.Signatures {
+		if err := sig.Verify(bv.Digest, s); err == nil {
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("no valid signature found for %s %s", name, version)
+}
+
+// withoutProvenance returns a shallow copy of the index with its loaded
+// provenance cleared, so the index content can be re-marshaled exactly as
+// it was when it was originally signed.
+func (i *Index) withoutProvenance() *Index {
+	cp := *i
+	cp.provenance = ""
+	return &cp
+}
+
+// digestBytes returns the sha256 digest of b as "sha256:<hex>".
+func digestBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("sha256:%x", sum)
 }
 
 // Merge merges the src index into i (dest).
 //
-// This merges by name and version.
-//
-// If one of the entries in the destination index does _not_ already exist, it is added.
-// In all other cases, the existing record is preserved.
-func (i *Index) Merge(src Index) {
-	for name, versionMap := range src {
-		for version, digest := range versionMap {
-			if !i.Has(name, version) {
-				i.Add(name, version, digest)
+// This merges by name and version. When both indexes carry an entry for the
+// same name and version, the entry with the more recent Created timestamp
+// wins, so a freshly re-signed or re-described bundle can replace a stale one.
+func (i *Index) Merge(src *Index) {
+	for name, versions := range src.Entries {
+		for _, srcVer := range versions {
+			dstVer := i.find(name, srcVer.Version)
+			if dstVer == nil {
+				i.Add(srcVer.Metadata, srcVer.Digest, srcVer.Size, srcVer.Created)
+				continue
+			}
+			if srcVer.Created.After(dstVer.Created) {
+				*dstVer = *srcVer
 			}
 		}
 	}
 }
 
+// Find returns the entry for the given name and exact version, or nil if no
+// such entry exists.
+func (i *Index) Find(name, version string) *BundleVersion {
+	return i.find(name, version)
+}
+
+// find returns the entry for the given name and exact version, or nil.
+func (i *Index) find(name, version string) *BundleVersion {
+	for _, bv := range i.Entries[name] {
+		if bv.Version == version {
+			return bv
+		}
+	}
+	return nil
+}
+
+// findByDigest returns the entry for the given name and digest, or nil.
+func (i *Index) findByDigest(name, digest string) *BundleVersion {
+	for _, bv := range i.Entries[name] {
+		if bv.Digest == digest {
+			return bv
+		}
+	}
+	return nil
+}
+
 // loadIndex loads an index file and does minimal validity checking.
-func loadIndex(r io.Reader) (Index, error) {
-	i := Index{}
-	if err := json.NewDecoder(r).Decode(&i); err != nil && err != io.EOF {
-		return i, err
+//
+// For backward compatibility, an index written in the legacy flat
+// map[string]map[string]string format (name -> version -> digest, with no
+// metadata) is transparently upgraded to the current struct form.
+func loadIndex(r io.Reader) (*Index, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
 	}
-	return i, nil
-}
\ No newline at end of file
+	if len(bytes.TrimSpace(data)) == 0 {
+		return NewIndex(), nil
+	}
+
+	i := &Index{}
+	if err := json.Unmarshal(data, i); err != nil {
+		return nil, err
+	}
+	if i.Entries != nil {
+		if i.APIVersion == "" {
+			return nil, ErrNoAPIVersion
+		}
+		for name, versions := range i.Entries {
+			sort.Sort(versions)
+			i.Entries[name] = versions
+		}
+		return i, nil
+	}
+
+	// Not in the current format: try the legacy flat digest map.
+	legacy := map[string]map[string]string{}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+
+	upgraded := NewIndex()
+	for name, vs := range legacy {
+		for version, digest := range vs {
+			upgraded.Add(Metadata{Name: name, Version: version}, digest, 0, time.Time{})
+		}
+	}
+	return upgraded, nil
+}
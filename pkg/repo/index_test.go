@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadIndexBuffer_MigratesLegacyFormat(t *testing.T) {
+	legacy := []byte(`{"mybundle":{"1.0.0":"sha256:abc"}}`)
+
+	idx, err := LoadIndexBuffer(legacy)
+	if err != nil {
+		t.Fatalf("LoadIndexBuffer: %v", err)
+	}
+	if idx.APIVersion != APIVersionV1 {
+		t.Fatalf("expected migrated index to carry APIVersion %q, got %q", APIVersionV1, idx.APIVersion)
+	}
+
+	digest, err := idx.Get("mybundle", "1.0.0")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if digest != "sha256:abc" {
+		t.Fatalf("expected digest sha256:abc, got %q", digest)
+	}
+}
+
+func TestLoadIndexBuffer_RejectsMissingAPIVersion(t *testing.T) {
+	data := []byte(`{"entries":{}}`)
+
+	if _, err := LoadIndexBuffer(data); err != ErrNoAPIVersion {
+		t.Fatalf("expected ErrNoAPIVersion, got %v", err)
+	}
+}
+
+func TestIndexMerge_PrefersNewerCreated(t *testing.T) {
+	dst := NewIndex()
+	dst.Add(Metadata{Name: "mybundle", Version: "1.0.0"}, "sha256:old", 0, time.Unix(100, 0))
+
+	src := NewIndex()
+	src.Add(Metadata{Name: "mybundle", Version: "1.0.0", Description: "updated"}, "sha256:new", 0, time.Unix(200, 0))
+
+	dst.Merge(src)
+
+	bv := dst.Find("mybundle", "1.0.0")
+	if bv == nil {
+		t.Fatal("expected an entry to exist after merge")
+	}
+	if bv.Digest != "sha256:new" {
+		t.Fatalf("expected merge to prefer the newer entry, got digest %q", bv.Digest)
+	}
+}
+
+func TestIndexMerge_KeepsNewerDestinationOverStaleSrc(t *testing.T) {
+	dst := NewIndex()
+	dst.Add(Metadata{Name: "mybundle", Version: "1.0.0"}, "sha256:current", 0, time.Unix(200, 0))
+
+	src := NewIndex()
+	src.Add(Metadata{Name: "mybundle", Version: "1.0.0"}, "sha256:stale", 0, time.Unix(100, 0))
+
+	dst.Merge(src)
+
+	bv := dst.Find("mybundle", "1.0.0")
+	if bv.Digest != "sha256:current" {
+		t.Fatalf("expected merge to keep the newer destination entry, got digest %q", bv.Digest)
+	}
+}
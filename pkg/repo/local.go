@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LocalRepository is a Repository backed by a directory on the local
+// filesystem, used for air-gapped installs and for testing.
+type LocalRepository struct {
+	name string
+	dir  string
+}
+
+// NewLocalRepository creates a LocalRepository rooted at dir.
+func NewLocalRepository(name, dir string) *LocalRepository {
+	return &LocalRepository{name: name, dir: dir}
+}
+
+// Name returns the configured name of the repository.
+func (r *LocalRepository) Name() string { return r.name }
+
+func (r *LocalRepository) indexPath() string {
+	return filepath.Join(r.dir, "index.json")
+}
+
+// Fetch loads the repository's index from disk.
+func (r *LocalRepository) Fetch() (*Index, error) {
+	return LoadIndex(r.indexPath())
+}
+
+// ListVersions returns the known versions of name in the repository's index.
+func (r *LocalRepository) ListVersions(name string) ([]string, error) {
+	idx, err := r.Fetch()
+	if err != nil {
+		return nil, err
+	}
+	vs, ok := idx.Entries[name]
+	if !ok {
+		return nil, ErrNoBundleName
+	}
+	versions := make([]string, 0, len(vs))
+	for _, v := range vs {
+		versions = append(versions, v.Version)
+	}
+	return versions, nil
+}
+
+// Resolve returns the digest for name/version from the repository's index.
+func (r *LocalRepository) Resolve(name, version string) (string, error) {
+	idx, err := r.Fetch()
+	if err != nil {
+		return "", err
+	}
+	return idx.Get(name, version)
+}
+
+// FetchArtifact opens the previously pushed artifact stored under digest.
+func (r *LocalRepository) FetchArtifact(digest string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(r.dir, digest+".tgz"))
+}
+
+// Push writes artifact into the repository directory, keyed by its content
+// digest, and records it in the repository's index.
+func (r *LocalRepository) Push(md Metadata, artifact io.Reader) (*BundleVersion, error) {
+	data, err := ioutil.ReadAll(artifact)
+	if err != nil {
+		return nil, err
+	}
+	digest := digestBytes(data)
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(r.dir, digest+".tgz"), data, 0644); err != nil {
+		return nil, err
+	}
+
+	idx, err := r.Fetch()
+	if err != nil {
+		idx = NewIndex()
+	}
+	idx.Add(md, digest, int64(len(data)), time.Now())
+	if err := idx.WriteFile(r.indexPath(), 0644); err != nil {
+		return nil, err
+	}
+	return idx.findByDigest(md.Name, digest), nil
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OCIRepository is a Repository backed by an OCI registry, where each
+// bundle version is stored as a tag of a repository matching the bundle
+// name (e.g. registry.example.com/bundles/my-bundle:1.2.3).
+type OCIRepository struct {
+	name     string
+	registry string
+	client   *http.Client
+}
+
+// NewOCIRepository creates an OCIRepository for the given registry host and
+// repository path prefix, e.g. "registry.example.com/bundles".
+func NewOCIRepository(name, registry string) *OCIRepository {
+	return &OCIRepository{name: name, registry: strings.TrimSuffix(registry, "/"), client: http.DefaultClient}
+}
+
+// Name returns the configured name of the repository.
+func (r *OCIRepository) Name() string { return r.name }
+
+// Fetch returns an empty Index: OCI registries have no single index
+// endpoint, so ListVersions and Resolve query the registry directly
+// instead of relying on a cached, merged index.
+func (r *OCIRepository) Fetch() (*Index, error) {
+	return NewIndex(), nil
+}
+
+type ociTagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// ListVersions lists the tags published for name under this registry.
+func (r *OCIRepository) ListVersions(name string) ([]string, error) {
+	resp, err := r.client.Get(fmt.Sprintf("https://%s/v2/%s/tags/list", r.registry, name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing tags for %s: unexpected status %s", name, resp.Status)
+	}
+
+	var list ociTagList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Tags, nil
+}
+
+// Resolve returns the registry's content digest for the manifest tagged
+// name:version.
+func (r *OCIRepository) Resolve(name, version string) (string, error) {
+	req, err := http.NewRequest(http.MethodHead, fmt.Sprintf("https://%s/v2/%s/manifests/%s", r.registry, name, version), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.oci.image.manifest.v1+json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s:%s: unexpected status %s", name, version, resp.Status)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry did not return a content digest for %s:%s", name, version)
+	}
+	return digest, nil
+}
+
+// Push is not yet supported for OCI registries: bundles are published with
+// existing OCI tooling and resolved from here, not uploaded through it.
+func (r *OCIRepository) Push(md Metadata, artifact io.Reader) (*BundleVersion, error) {
+	return nil, fmt.Errorf("pushing to an OCI repository is not yet supported")
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Origin records where a cached bundle artifact actually came from: its
+// source repository, the VCS revision it was built from (when known), the
+// digest it resolved to, and the HTTP validator seen when it was fetched.
+// It is persisted as a sidecar file next to the cached artifact so a future
+// refresh can tell whether anything changed without re-downloading it.
+type Origin struct {
+	// Source is the URL of the bundle's source repository, as recorded in
+	// its index entry.
+	Source string `json:"source,omitempty"`
+	// VCS is the version control system backing Source, if known (e.g. "git").
+	VCS string `json:"vcs,omitempty"`
+	// Revision is the VCS commit or tag the bundle was built from, if known.
+	Revision string `json:"revision,omitempty"`
+	// Digest is the resolved content digest of the cached artifact.
+	Digest string `json:"digest,omitempty"`
+	// ETag is the HTTP validator returned when the artifact was fetched, if any.
+	ETag string `json:"etag,omitempty"`
+	// FetchedAt is when the artifact was downloaded.
+	FetchedAt time.Time `json:"fetchedAt,omitempty"`
+}
+
+// ConditionalArtifactFetcher is implemented by Repository backends that can
+// skip a re-download when a previously seen ETag is still valid.
+type ConditionalArtifactFetcher interface {
+	// FetchArtifactIfChanged fetches the artifact stored under digest
+	// unless etag (from a previous fetch) is still current, in which case
+	// rc is nil and changed is false.
+	FetchArtifactIfChanged(digest, etag string) (rc io.ReadCloser, newETag string, changed bool, err error)
+}
+
+func originPath(cacheDir, digest string) string {
+	return filepath.Join(cacheDir, digest+".origin.json")
+}
+
+func loadOrigin(cacheDir, digest string) (*Origin, error) {
+	data, err := ioutil.ReadFile(originPath(cacheDir, digest))
+	if err != nil {
+		return nil, err
+	}
+	o := &Origin{}
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *Origin) writeFile(cacheDir string) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(o, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(originPath(cacheDir, o.Digest), b, 0644)
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Repository is a source of bundle index data that duffle can fetch from,
+// query, and — where supported — push bundles to.
+type Repository interface {
+	// Name returns the configured name of the repository.
+	Name() string
+	// Fetch retrieves the repository's index.
+	Fetch() (*Index, error)
+	// ListVersions returns the known versions of the named bundle.
+	ListVersions(name string) ([]string, error)
+	// Resolve returns the digest for the given name and version.
+	Resolve(name, version string) (string, error)
+	// Push uploads a bundle artifact, records it under md, and returns the
+	// entry that was added to the repository's index.
+	Push(md Metadata, artifact io.Reader) (*BundleVersion, error)
+}
+
+// NewRepository constructs the Repository implementation appropriate for
+// cfg.URL's scheme: "oci://" for an OCI registry, "http(s)://" for a plain
+// HTTP index, and "file://" (or a bare path) for a local directory.
+//
+// cacheDir is used by repositories that cache fetched data on disk; it is
+// typically filepath.Join(h.Repositories(), cfg.Name) for a home.Home h.
+func NewRepository(cfg RepoConfig, cacheDir string) (Repository, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository URL %q: %v", cfg.URL, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return NewHTTPRepository(cfg.Name, cfg.URL, cacheDir), nil
+	case "oci":
+		return NewOCIRepository(cfg.Name, u.Host+u.Path), nil
+	case "file", "":
+		path := u.Path
+		if path == "" {
+			path = cfg.URL
+		}
+		return NewLocalRepository(cfg.Name, path), nil
+	default:
+		return nil, fmt.Errorf("unsupported repository scheme %q", u.Scheme)
+	}
+}
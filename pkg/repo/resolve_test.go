@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIndexResolve_PicksHighestStableVersion(t *testing.T) {
+	idx := NewIndex()
+	for _, v := range []string{"1.0.0", "1.2.0", "1.1.0"} {
+		idx.Add(Metadata{Name: "mybundle", Version: v}, "sha256:"+v, 0, time.Time{})
+	}
+
+	res, err := idx.Resolve("mybundle", "", ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Version != "1.2.0" {
+		t.Fatalf("expected 1.2.0, got %s", res.Version)
+	}
+}
+
+func TestIndexResolve_SkipsPrereleaseByDefault(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Metadata{Name: "mybundle", Version: "2.0.0-beta.1"}, "sha256:beta", 0, time.Time{})
+	idx.Add(Metadata{Name: "mybundle", Version: "1.0.0"}, "sha256:stable", 0, time.Time{})
+
+	res, err := idx.Resolve("mybundle", "", ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Version != "1.0.0" {
+		t.Fatalf("expected prerelease to be skipped, got %s", res.Version)
+	}
+
+	res, err = idx.Resolve("mybundle", "", ResolveOptions{AllowPrerelease: true})
+	if err != nil {
+		t.Fatalf("Resolve with AllowPrerelease: %v", err)
+	}
+	if res.Version != "2.0.0-beta.1" {
+		t.Fatalf("expected prerelease allowed to win, got %s", res.Version)
+	}
+}
+
+func TestIndexResolve_ExactPrereleaseConstraint(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Metadata{Name: "mybundle", Version: "2.0.0-beta.2"}, "sha256:beta2", 0, time.Time{})
+	idx.Add(Metadata{Name: "mybundle", Version: "2.0.0-beta.1"}, "sha256:beta1", 0, time.Time{})
+	idx.Add(Metadata{Name: "mybundle", Version: "1.0.0"}, "sha256:stable", 0, time.Time{})
+
+	res, err := idx.Resolve("mybundle", "=2.0.0-beta.2", ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Version != "2.0.0-beta.2" {
+		t.Fatalf("expected the exact prerelease pinned by the constraint, got %s", res.Version)
+	}
+
+	res, err = idx.Resolve("mybundle", "<=2.0.0-beta.2", ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Version != "2.0.0-beta.2" {
+		t.Fatalf("expected the highest prerelease allowed by the range, got %s", res.Version)
+	}
+}
+
+func TestIndexResolve_SkipsIncompatibleByDefault(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Metadata{Name: "mybundle", Version: "3.0.0+incompatible"}, "sha256:incompatible", 0, time.Time{})
+	idx.Add(Metadata{Name: "mybundle", Version: "1.0.0"}, "sha256:stable", 0, time.Time{})
+
+	res, err := idx.Resolve("mybundle", "", ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Version != "1.0.0" {
+		t.Fatalf("expected +incompatible to be skipped, got %s", res.Version)
+	}
+
+	res, err = idx.Resolve("mybundle", "", ResolveOptions{AllowIncompatible: true})
+	if err != nil {
+		t.Fatalf("Resolve with AllowIncompatible: %v", err)
+	}
+	if !res.Incompatible || res.Version != "3.0.0+incompatible" {
+		t.Fatalf("expected the +incompatible version to be resolved and flagged, got %+v", res)
+	}
+}
+
+func TestIndexResolve_PreferredOverridesConstraint(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(Metadata{Name: "mybundle", Version: "1.0.0"}, "sha256:one", 0, time.Time{})
+	idx.Add(Metadata{Name: "mybundle", Version: "2.0.0"}, "sha256:two", 0, time.Time{})
+
+	res, err := idx.Resolve("mybundle", "^1.0.0", ResolveOptions{Preferred: "2.0.0"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.Version != "2.0.0" {
+		t.Fatalf("expected Preferred to win over constraint, got %s", res.Version)
+	}
+}
@@ -0,0 +1,13 @@
+// Package version holds the duffle build version, used wherever code needs
+// to compare itself against a compatibility constraint (for example, a
+// plugin's Require field).
+package version
+
+// Version is the version of this duffle build. It is "canary" unless
+// overridden at build time via:
+//
+//	-ldflags "-X github.com/jcsirot/duffle/pkg/version.Version=v0.5.0"
+//
+// "canary" is not a semantic version, so a plugin that declares a Require
+// constraint cannot be resolved against a canary build.
+var Version = "canary"